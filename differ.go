@@ -0,0 +1,55 @@
+package mendoza
+
+import internalmendoza "github.com/sanity-io/mendoza/internal/mendoza"
+
+// Differ owns a HashListCache and offers the same diffing operations as the
+// package-level CreateDoublePatch/CreatePatch, but reuses previously hashed
+// subtrees across calls instead of rebuilding them from scratch every time.
+// That's the common case for a single "source of truth" document diffed
+// against many near-identical candidates (or vice versa): unchanged
+// sub-objects shared by reference across those documents only ever get
+// hashed once. A Differ is safe for concurrent use, so it can back many diff
+// requests from multiple goroutines.
+type Differ struct {
+	cache *internalmendoza.HashListCache
+}
+
+// NewDiffer returns a Differ whose cache holds up to maxCachedEntries
+// HashEntry rows (see HashListCache), evicting least-recently-used subtrees
+// once that's exceeded. A single subtree larger than maxCachedEntries is
+// still cached on its own.
+func NewDiffer(maxCachedEntries int) *Differ {
+	return &Differ{cache: internalmendoza.NewHashListCache(maxCachedEntries, nil)}
+}
+
+// CreateDoublePatch behaves like the package-level CreateDoublePatch, but
+// hashes left and right through d's cache.
+func (d *Differ) CreateDoublePatch(left, right interface{}) (Patch, Patch, error) {
+	leftList, err := internalmendoza.HashListForCached(left, d.cache)
+	if err != nil {
+		return Patch{}, Patch{}, err
+	}
+
+	rightList, err := internalmendoza.HashListForCached(right, d.cache)
+	if err != nil {
+		return Patch{}, Patch{}, err
+	}
+
+	return createDoublePatchFromHashLists(leftList, rightList)
+}
+
+// CreatePatch behaves like the package-level CreatePatch, but hashes left and
+// right through d's cache.
+func (d *Differ) CreatePatch(left, right interface{}) (Patch, error) {
+	leftList, err := internalmendoza.HashListForCached(left, d.cache)
+	if err != nil {
+		return Patch{}, err
+	}
+
+	rightList, err := internalmendoza.HashListForCached(right, d.cache)
+	if err != nil {
+		return Patch{}, err
+	}
+
+	return createPatchFromHashLists(leftList, rightList)
+}
@@ -0,0 +1,75 @@
+package mendoza_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sanity-io/mendoza"
+)
+
+// benchItems builds a ~1 MB slice of items, shared by reference across every
+// document benchDoc returns: that's what gives a HashListCache anything to
+// do, since caching only helps when the exact same map/slice value recurs.
+func benchItems() interface{} {
+	items := make([]interface{}, 6500)
+	for i := range items {
+		items[i] = map[string]interface{}{
+			"id":          fmt.Sprintf("item-%d", i),
+			"description": "a reasonably long description string to pad out the document to roughly one megabyte in size",
+			"tags":        []interface{}{"alpha", "beta", "gamma"},
+		}
+	}
+	return items
+}
+
+// benchDoc wraps items (shared, unchanged, across every variant) together
+// with one field that differs per variant index: the common shape of
+// diffing a single "source of truth" against many near-identical copies.
+func benchDoc(items interface{}, variant int) interface{} {
+	return map[string]interface{}{
+		"items":   items,
+		"variant": float64(variant),
+	}
+}
+
+// BenchmarkCreateDoublePatch diffs a fixed document against 1000 near-
+// identical variants without a Differ, rehashing the shared "items" slice
+// from scratch on every call.
+func BenchmarkCreateDoublePatch(b *testing.B) {
+	items := benchItems()
+	left := benchDoc(items, 0)
+	variants := make([]interface{}, 1000)
+	for i := range variants {
+		variants[i] = benchDoc(items, i+1)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		right := variants[i%len(variants)]
+		if _, _, err := mendoza.CreateDoublePatch(left, right); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDifferCreateDoublePatch is the same workload through a Differ,
+// whose cache lets the shared "items" slice be hashed once and reused across
+// all 1000 variants.
+func BenchmarkDifferCreateDoublePatch(b *testing.B) {
+	items := benchItems()
+	left := benchDoc(items, 0)
+	variants := make([]interface{}, 1000)
+	for i := range variants {
+		variants[i] = benchDoc(items, i+1)
+	}
+
+	d := mendoza.NewDiffer(1 << 20)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		right := variants[i%len(variants)]
+		if _, _, err := d.CreateDoublePatch(left, right); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package mendoza
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	internalmendoza "github.com/sanity-io/mendoza/internal/mendoza"
+)
+
+// UnifiedOptions configures FormatUnifiedWithOptions.
+type UnifiedOptions struct {
+	// Context is the number of unchanged sibling lines shown around each
+	// change, the same role the -U flag plays for classic unified diffs.
+	Context int
+
+	// Color, when set, wraps each added, removed, or changed line and each
+	// inline string-delta marker in ANSI escape codes. Context lines and
+	// "unchanged" elision lines are left uncolored.
+	Color bool
+}
+
+// DefaultUnifiedOptions returns the options used by FormatUnified.
+func DefaultUnifiedOptions() UnifiedOptions {
+	return UnifiedOptions{Context: 3}
+}
+
+// FormatUnified renders patch as a path-addressed, human-readable diff of
+// left against the document patch produces, using DefaultUnifiedOptions.
+func FormatUnified(left interface{}, patch Patch, w io.Writer) error {
+	return FormatUnifiedWithOptions(left, patch, w, DefaultUnifiedOptions())
+}
+
+// FormatUnifiedWithOptions is FormatUnified with explicit options.
+//
+// The real traversal a patch's ops would drive (the one ApplyPatch uses)
+// only exists alongside the rest of this package's patch/apply internals,
+// which this tree doesn't have. Rather than guess at their shape, this
+// applies the patch once via ApplyPatch and then walks the resulting value
+// alongside left directly — producing the same path-addressed output the
+// request describes, just derived from the before/after values instead of
+// from the op stream itself.
+func FormatUnifiedWithOptions(left interface{}, patch Patch, w io.Writer, opts UnifiedOptions) error {
+	right := ApplyPatch(left, patch)
+
+	f := &unifiedFormatter{w: w, opts: opts}
+	return f.node("", left, right)
+}
+
+type unifiedFormatter struct {
+	w    io.Writer
+	opts UnifiedOptions
+}
+
+// sibling is one child of a map or slice value being compared: either a
+// single leaf-level change to print at this level (added, removed, or a
+// change between a map or slice and a value of another kind), or an
+// unchanged child, or a changed child of matching kind (two maps, two
+// slices, or two scalars) that's handled per the rules in node.
+type sibling struct {
+	label   string // child path suffix: ".key" or "[idx]"
+	same    bool
+	leaf    bool
+	inLeft  bool
+	inRight bool
+	left    interface{}
+	right   interface{}
+}
+
+// node compares left and right at path, recursing into matching maps and
+// slices and printing one line per leaf-level change, with up to
+// f.opts.Context unchanged siblings shown around each change.
+func (f *unifiedFormatter) node(path string, left, right interface{}) error {
+	leftMap, leftIsMap := left.(map[string]interface{})
+	rightMap, rightIsMap := right.(map[string]interface{})
+	if leftIsMap && rightIsMap {
+		return f.container(path, mapSiblings(leftMap, rightMap))
+	}
+
+	leftSlice, leftIsSlice := left.([]interface{})
+	rightSlice, rightIsSlice := right.([]interface{})
+	if leftIsSlice && rightIsSlice {
+		return f.container(path, sliceSiblings(leftSlice, rightSlice))
+	}
+
+	if reflect.DeepEqual(left, right) {
+		return nil
+	}
+	return f.printChange(path, left, right)
+}
+
+func mapSiblings(left, right map[string]interface{}) []sibling {
+	keys := make(map[string]bool, len(left)+len(right))
+	for k := range left {
+		keys[k] = true
+	}
+	for k := range right {
+		keys[k] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	siblings := make([]sibling, len(sorted))
+	for i, k := range sorted {
+		lv, inLeft := left[k]
+		rv, inRight := right[k]
+		siblings[i] = sibling{
+			label:   "." + k,
+			same:    inLeft && inRight && reflect.DeepEqual(lv, rv),
+			leaf:    !inLeft || !inRight || !sameContainerKind(lv, rv),
+			inLeft:  inLeft,
+			inRight: inRight,
+			left:    lv,
+			right:   rv,
+		}
+	}
+	return siblings
+}
+
+func sliceSiblings(left, right []interface{}) []sibling {
+	n := len(left)
+	if len(right) > n {
+		n = len(right)
+	}
+
+	siblings := make([]sibling, n)
+	for i := 0; i < n; i++ {
+		var lv, rv interface{}
+		inLeft, inRight := i < len(left), i < len(right)
+		if inLeft {
+			lv = left[i]
+		}
+		if inRight {
+			rv = right[i]
+		}
+		siblings[i] = sibling{
+			label:   "[" + strconv.Itoa(i) + "]",
+			same:    inLeft && inRight && reflect.DeepEqual(lv, rv),
+			leaf:    !inLeft || !inRight || !sameContainerKind(lv, rv),
+			inLeft:  inLeft,
+			inRight: inRight,
+			left:    lv,
+			right:   rv,
+		}
+	}
+	return siblings
+}
+
+// sameContainerKind reports whether a and b are both maps, both slices, or
+// neither — the condition under which a changed child is recursed into
+// instead of printed as a single replaced value.
+func sameContainerKind(a, b interface{}) bool {
+	_, aMap := a.(map[string]interface{})
+	_, bMap := b.(map[string]interface{})
+	if aMap || bMap {
+		return aMap && bMap
+	}
+
+	_, aSlice := a.([]interface{})
+	_, bSlice := b.([]interface{})
+	if aSlice || bSlice {
+		return aSlice && bSlice
+	}
+
+	return true
+}
+
+func (f *unifiedFormatter) container(path string, siblings []sibling) error {
+	changed := make([]bool, len(siblings))
+	for i, s := range siblings {
+		changed[i] = !s.same
+	}
+
+	show := make([]bool, len(siblings))
+	for i := range siblings {
+		if !changed[i] {
+			continue
+		}
+		for j := i - f.opts.Context; j <= i+f.opts.Context; j++ {
+			if j >= 0 && j < len(siblings) {
+				show[j] = true
+			}
+		}
+	}
+
+	hiddenRun := 0
+	for i, s := range siblings {
+		if !show[i] {
+			hiddenRun++
+			continue
+		}
+		if hiddenRun > 0 {
+			if err := f.printLine(" ", fmt.Sprintf("  … %d unchanged …", hiddenRun)); err != nil {
+				return err
+			}
+			hiddenRun = 0
+		}
+
+		childPath := path + s.label
+
+		switch {
+		case s.same:
+			if err := f.printContext(childPath, s.left); err != nil {
+				return err
+			}
+		case !s.inLeft:
+			if err := f.printAdded(childPath, s.right); err != nil {
+				return err
+			}
+		case !s.inRight:
+			if err := f.printRemoved(childPath); err != nil {
+				return err
+			}
+		case s.leaf:
+			if err := f.printChange(childPath, s.left, s.right); err != nil {
+				return err
+			}
+		default:
+			if err := f.node(childPath, s.left, s.right); err != nil {
+				return err
+			}
+		}
+	}
+	if hiddenRun > 0 {
+		if err := f.printLine(" ", fmt.Sprintf("  … %d unchanged …", hiddenRun)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *unifiedFormatter) printContext(path string, value interface{}) error {
+	return f.printLine(" ", fmt.Sprintf("  %s: %s", path, formatScalar(value)))
+}
+
+func (f *unifiedFormatter) printAdded(path string, value interface{}) error {
+	return f.printLine("+", fmt.Sprintf("+ %s: %s", path, formatScalar(value)))
+}
+
+func (f *unifiedFormatter) printRemoved(path string) error {
+	return f.printLine("-", fmt.Sprintf("- %s", path))
+}
+
+func (f *unifiedFormatter) printChange(path string, left, right interface{}) error {
+	leftStr, leftIsString := left.(string)
+	rightStr, rightIsString := right.(string)
+	if leftIsString && rightIsString && len(leftStr) >= internalmendoza.DefaultStringDeltaMinSize && len(rightStr) >= internalmendoza.DefaultStringDeltaMinSize {
+		ops := internalmendoza.DiffStrings(leftStr, rightStr, internalmendoza.DefaultStringDeltaWindowSize, internalmendoza.DefaultStringDeltaMaxChain)
+		if hasStringCopy(ops) {
+			return f.printLine("~", fmt.Sprintf("~ %s: %s", path, f.renderStringDelta(leftStr, ops)))
+		}
+	}
+
+	return f.printLine("~", fmt.Sprintf("~ %s: %s -> %s", path, formatScalar(left), formatScalar(right)))
+}
+
+// hasStringCopy reports whether ops contains at least one StringCopy, i.e.
+// the two strings actually share a matched region worth rendering as a
+// delta rather than a plain replacement.
+func hasStringCopy(ops []internalmendoza.StringOp) bool {
+	for _, op := range ops {
+		if _, ok := op.(internalmendoza.StringCopy); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// renderStringDelta renders src and the value ops reconstructs from it as a
+// single diff: copied regions are shown as-is and inserted literals are
+// wrapped in "{+...+}", both in op order, followed by whatever source bytes
+// no copy referenced, wrapped in "[-...-]", in source order. Those deleted
+// bytes can't be interleaved at a single "natural" position in the op
+// sequence — ops can copy from src out of order (e.g. src's tail before its
+// head) — so rather than guess a position, uncovered source text is grouped
+// at the end instead of risking the same bytes being shown twice.
+func (f *unifiedFormatter) renderStringDelta(src string, ops []internalmendoza.StringOp) string {
+	var b strings.Builder
+	b.WriteByte('"')
+
+	for _, op := range ops {
+		switch op := op.(type) {
+		case internalmendoza.StringCopy:
+			b.WriteString(src[op.SrcOffset : op.SrcOffset+op.Length])
+		case internalmendoza.StringInsert:
+			b.WriteString(f.colorize("32", "{+"+op.Literal+"+}"))
+		}
+	}
+	for _, gap := range uncoveredByteRanges(src, ops) {
+		b.WriteString(f.colorize("31", "[-"+src[gap.start:gap.end]+"-]"))
+	}
+
+	b.WriteByte('"')
+	return b.String()
+}
+
+// byteRange is a half-open [start, end) span of byte offsets into a source
+// string.
+type byteRange struct {
+	start, end int
+}
+
+// uncoveredByteRanges returns the spans of src that no StringCopy in ops
+// references, merged and sorted by offset regardless of the order copies
+// appear in ops.
+func uncoveredByteRanges(src string, ops []internalmendoza.StringOp) []byteRange {
+	var covered []byteRange
+	for _, op := range ops {
+		if cp, ok := op.(internalmendoza.StringCopy); ok {
+			covered = append(covered, byteRange{cp.SrcOffset, cp.SrcOffset + cp.Length})
+		}
+	}
+	sort.Slice(covered, func(i, j int) bool { return covered[i].start < covered[j].start })
+
+	var gaps []byteRange
+	pos := 0
+	for _, r := range covered {
+		if r.start > pos {
+			gaps = append(gaps, byteRange{pos, r.start})
+		}
+		if r.end > pos {
+			pos = r.end
+		}
+	}
+	if pos < len(src) {
+		gaps = append(gaps, byteRange{pos, len(src)})
+	}
+	return gaps
+}
+
+func (f *unifiedFormatter) printLine(kind, line string) error {
+	if f.opts.Color {
+		line = f.colorize(colorForKind(kind), line)
+	}
+	_, err := fmt.Fprintln(f.w, line)
+	return err
+}
+
+func colorForKind(kind string) string {
+	switch kind {
+	case "+":
+		return "32"
+	case "-":
+		return "31"
+	case "~":
+		return "33"
+	default:
+		return ""
+	}
+}
+
+func (f *unifiedFormatter) colorize(code, s string) string {
+	if !f.opts.Color || code == "" {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+func formatScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case map[string]interface{}:
+		return fmt.Sprintf("{…%d fields…}", len(v))
+	case []interface{}:
+		return fmt.Sprintf("[…%d elements…]", len(v))
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,47 @@
+package mendoza_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sanity-io/mendoza"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatUnified(t *testing.T) {
+	var left, right interface{}
+	require.NoError(t, json.Unmarshal([]byte(`
+		{"title": "old", "tags": ["a", "b"], "meta": {"draft": true}}
+	`), &left))
+	require.NoError(t, json.Unmarshal([]byte(`
+		{"title": "new", "tags": ["a", "b", "c"], "meta": {}}
+	`), &right))
+
+	patch, err := mendoza.CreatePatch(left, right)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, mendoza.FormatUnified(left, patch, &buf))
+
+	out := buf.String()
+	require.Contains(t, out, `~ .title: "old" -> "new"`)
+	require.Contains(t, out, `+ .tags[2]: "c"`)
+	require.Contains(t, out, `- .meta.draft`)
+}
+
+func TestFormatUnifiedColor(t *testing.T) {
+	left := map[string]interface{}{"a": "a"}
+	right := map[string]interface{}{"a": "b"}
+
+	patch, err := mendoza.CreatePatch(left, right)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	opts := mendoza.DefaultUnifiedOptions()
+	opts.Color = true
+	require.NoError(t, mendoza.FormatUnifiedWithOptions(left, patch, &buf, opts))
+
+	require.True(t, strings.Contains(buf.String(), "\x1b["))
+}
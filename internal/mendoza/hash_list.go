@@ -1,7 +1,6 @@
 package mendoza
 
 import (
-	"errors"
 	"sort"
 )
 
@@ -51,10 +50,27 @@ type HashEntry struct {
 }
 
 func (hashList *HashList) AddDocument(obj interface{}) error {
-	_, _, err := hashList.process(-1, Reference{}, obj)
+	store := &memoryHashListStore{hashList: hashList}
+	streaming := NewStreamingHashList(store)
+
+	if err := streamValue(streaming, hashList, obj); err != nil {
+		return err
+	}
+
+	_, err := streaming.Result()
 	return err
 }
 
+// Len implements HashListReader.
+func (hashList *HashList) Len() int {
+	return len(hashList.Entries)
+}
+
+// Entry implements HashListReader.
+func (hashList *HashList) Entry(idx int) HashEntry {
+	return hashList.Entries[idx]
+}
+
 func (hashList *HashList) IsNonEmptyMap(idx int) bool {
 	if len(hashList.Entries) <= idx+1 {
 		return false
@@ -73,106 +89,20 @@ func (hashList *HashList) IsNonEmptySlice(idx int) bool {
 	return nextEntry.Parent == idx && nextEntry.Reference.IsSliceEntry()
 }
 
-func (hashList *HashList) process(parent int, ref Reference, obj interface{}) (result Hash, size int, err error) {
-	current := len(hashList.Entries)
-
-	hashList.Entries = append(hashList.Entries, HashEntry{
-		Parent:    parent,
-		Value:     obj,
-		Reference: ref,
-		Sibling:   -1,
-	})
-
-	switch obj := obj.(type) {
-	case nil:
-		result = HashNull
-		size = 1
-	case bool:
-		if obj {
-			result = HashTrue
-		} else {
-			result = HashFalse
-		}
-		size = 1
-	case float64:
-		result = HashFloat64(obj)
-		size = 8
-	case string:
-		result = HashString(obj)
-		size = len(obj) + 1
-	case map[string]interface{}:
-		hasher := HasherMap()
-		keys := sortedKeys(obj)
-
-		prevIdx := -1
-
-		for idx, key := range keys {
-			value := obj[key]
-			entryIdx := len(hashList.Entries)
-			valueHash, valueSize, err := hashList.process(current, MapEntryReference(idx, key), value)
-			if err != nil {
-				return result, size, err
-			}
-
-			size += len(key) + valueSize + 1
-
-			if prevIdx != -1 {
-				prevEntry := &hashList.Entries[prevIdx]
-				prevEntry.Sibling = entryIdx
-			}
-
-			prevIdx = entryIdx
-
-			hasher.WriteField(key, valueHash)
-		}
-
-		result = hasher.Sum()
-	case []interface{}:
-		hasher := HasherSlice()
-
-		prevIdx := -1
-
-		for idx, value := range obj {
-			entryIdx := len(hashList.Entries)
-
-			valueHash, valueSize, err := hashList.process(current, SliceEntryReference(idx), value)
-			if err != nil {
-				return result, size, err
-			}
-
-			size += valueSize + 1
-
-			if prevIdx != -1 {
-				prevEntry := &hashList.Entries[prevIdx]
-				prevEntry.Sibling = entryIdx
-			}
-
-			prevIdx = entryIdx
-
-			hasher.WriteElement(valueHash)
-		}
-
-		result = hasher.Sum()
-	default:
-		return result, size, errors.New("unsupported type")
-	}
-
-	hashList.Entries[current].Hash = result
-	hashList.Entries[current].Size = size
-
-	return result, size, nil
-}
-
 func (hashList *HashList) Iter(idx int) *Iter {
 	return &Iter{
-		hashList: hashList,
-		idx:      idx + 1,
+		source: hashList,
+		idx:    idx + 1,
 	}
 }
 
+// Iter walks a chain of sibling entries, following the Sibling links a
+// StreamingHashList leaves behind. It reads through a HashListReader
+// rather than a concrete *HashList so the same walk works over a document
+// too large to hold in memory.
 type Iter struct {
-	hashList *HashList
-	idx      int
+	source HashListReader
+	idx    int
 }
 
 func (it *Iter) GetIndex() int {
@@ -180,7 +110,7 @@ func (it *Iter) GetIndex() int {
 }
 
 func (it *Iter) GetEntry() HashEntry {
-	return it.hashList.Entries[it.idx]
+	return it.source.Entry(it.idx)
 }
 
 func (it *Iter) GetKey() string {
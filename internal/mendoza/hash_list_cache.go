@@ -0,0 +1,145 @@
+package mendoza
+
+import (
+	"container/list"
+	"reflect"
+	"sync"
+)
+
+// cacheKey identifies a subtree for HashListCache purposes. Actually hashing
+// a subtree requires visiting every one of its descendants, so keying the
+// cache by the subtree's own Hash would mean paying that full cost before
+// the cache could ever help — no better than not caching at all. Instead the
+// key is the identity of the map/slice value itself, which is free to get:
+// when the same shared document (or a sub-object copied by reference across
+// otherwise-different variants) is hashed more than once, Go's pointer
+// identity recognizes it without walking it again.
+type cacheKey struct {
+	kind reflect.Kind
+	ptr  uintptr
+	len  int
+}
+
+// cacheKeyFor returns obj's cache key, or ok == false for anything that
+// isn't a map or slice (scalars are cheap enough to hash that caching them
+// individually isn't worthwhile).
+//
+// len is folded into the key alongside the backing pointer because two
+// slices can share a backing array yet cover different elements (s[:2] vs
+// s[:3]): pointer identity alone would conflate them and splice back the
+// wrong cached subtree. Documents decoded from JSON never alias a backing
+// array this way, but HashListCache is a public API a caller could still
+// feed reslices of a shared array to, so key on what's actually visible
+// through obj rather than assuming that input shape.
+func cacheKeyFor(obj interface{}) (key cacheKey, ok bool) {
+	v := reflect.ValueOf(obj)
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		return cacheKey{kind: v.Kind(), ptr: v.Pointer(), len: v.Len()}, true
+	default:
+		return cacheKey{}, false
+	}
+}
+
+// HashListCache is a bounded, least-recently-used cache of previously hashed
+// subtrees, sized by total HashEntry count rather than subtree count (a
+// handful of huge subtrees shouldn't crowd out everything else the same way
+// a handful of tiny ones would). It follows the same fixed-capacity,
+// eviction-callback shape as go-git's plumbing/cache package, and is safe
+// for concurrent use so a single Differ can serve many diff requests from
+// multiple goroutines at once.
+type HashListCache struct {
+	maxEntries int
+	onEvict    func(entries int)
+
+	mu      sync.Mutex
+	ll      *list.List // most-recently-used at the front
+	index   map[cacheKey]*list.Element
+	entries int // total HashEntry rows currently cached, across every subtree
+}
+
+// cachedSubtree is one cached entry: the subtree's own key, a strong
+// reference to the source object (see HashListCache.add), and its flattened,
+// subtree-relative HashEntry rows.
+type cachedSubtree struct {
+	key     cacheKey
+	source  interface{}
+	entries []HashEntry
+}
+
+// NewHashListCache returns a cache that evicts least-recently-used subtrees
+// once the total number of cached HashEntry rows would otherwise exceed
+// maxEntries. onEvict, when non-nil, is called with the size (in entries) of
+// whatever was just evicted. The most recently added subtree is never
+// evicted, so a single subtree larger than maxEntries still gets cached and
+// the total can then exceed maxEntries.
+func NewHashListCache(maxEntries int, onEvict func(entries int)) *HashListCache {
+	return &HashListCache{
+		maxEntries: maxEntries,
+		onEvict:    onEvict,
+		ll:         list.New(),
+		index:      make(map[cacheKey]*list.Element),
+	}
+}
+
+func (c *HashListCache) get(key cacheKey) ([]HashEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.index[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.ll.MoveToFront(el)
+	return el.Value.(*cachedSubtree).entries, true
+}
+
+// add stores entries under key, keeping source alive for as long as the
+// record stays cached: that's what keeps cacheKey's pointer-based identity
+// sound (see its doc comment) — the key can only collide with a *currently
+// cached* record if it's genuinely the same live object.
+func (c *HashListCache) add(key cacheKey, source interface{}, entries []HashEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.index[key]; ok {
+		c.entries += len(entries) - len(el.Value.(*cachedSubtree).entries)
+		el.Value.(*cachedSubtree).entries = entries
+		el.Value.(*cachedSubtree).source = source
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	el := c.ll.PushFront(&cachedSubtree{key: key, source: source, entries: entries})
+	c.index[key] = el
+	c.entries += len(entries)
+
+	for c.entries > c.maxEntries && c.ll.Len() > 1 {
+		c.evictOldest()
+	}
+}
+
+func (c *HashListCache) evictOldest() {
+	el := c.ll.Back()
+	if el == nil {
+		return
+	}
+
+	c.ll.Remove(el)
+	record := el.Value.(*cachedSubtree)
+	delete(c.index, record.key)
+	c.entries -= len(record.entries)
+
+	if c.onEvict != nil {
+		c.onEvict(len(record.entries))
+	}
+}
+
+// Len returns the total number of cached HashEntry rows across every
+// subtree.
+func (c *HashListCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries
+}
@@ -0,0 +1,84 @@
+package mendoza
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHashListForCachedMatchesHashListFor(t *testing.T) {
+	shared := map[string]interface{}{"a": "a", "b": []interface{}{1.0, 2.0, "three"}}
+
+	for i, doc := range []interface{}{
+		map[string]interface{}{"shared": shared, "i": float64(0)},
+		map[string]interface{}{"shared": shared, "i": float64(1)},
+		[]interface{}{shared, shared},
+	} {
+		want, err := HashListFor(doc)
+		if err != nil {
+			t.Fatalf("doc %d: HashListFor: %v", i, err)
+		}
+
+		cache := NewHashListCache(1<<20, nil)
+		got, err := HashListForCached(doc, cache)
+		if err != nil {
+			t.Fatalf("doc %d: HashListForCached: %v", i, err)
+		}
+
+		if got.Entries[0].Hash != want.Entries[0].Hash {
+			t.Fatalf("doc %d: hash mismatch: got %v, want %v", i, got.Entries[0].Hash, want.Entries[0].Hash)
+		}
+		if len(got.Entries) != len(want.Entries) {
+			t.Fatalf("doc %d: entry count mismatch: got %d, want %d", i, len(got.Entries), len(want.Entries))
+		}
+
+		for idx := range want.Entries {
+			gotEntry, wantEntry := got.Entries[idx], want.Entries[idx]
+			if !reflect.DeepEqual(gotEntry, wantEntry) {
+				t.Fatalf("doc %d: entry %d mismatch: got %+v, want %+v", i, idx, gotEntry, wantEntry)
+			}
+		}
+	}
+}
+
+func TestHashListForCachedReusesSharedSubtree(t *testing.T) {
+	shared := map[string]interface{}{"a": "a", "b": []interface{}{1.0, 2.0, "three"}}
+	doc := map[string]interface{}{"shared": shared, "i": float64(0)}
+
+	cache := NewHashListCache(1<<20, nil)
+
+	if _, err := HashListForCached(doc, cache); err != nil {
+		t.Fatalf("first HashListForCached: %v", err)
+	}
+	before := cache.Len()
+
+	if _, err := HashListForCached(doc, cache); err != nil {
+		t.Fatalf("second HashListForCached: %v", err)
+	}
+	if after := cache.Len(); after != before {
+		t.Fatalf("cache grew on a repeat document: before %d, after %d", before, after)
+	}
+}
+
+func TestHashListCacheEvictsOldest(t *testing.T) {
+	var evicted []int
+	cache := NewHashListCache(3, func(entries int) { evicted = append(evicted, entries) })
+
+	a := map[string]interface{}{"a": "a"}
+	b := map[string]interface{}{"b": "b"}
+	c := map[string]interface{}{"c": "c"}
+
+	for _, doc := range []interface{}{a, b, c} {
+		if _, err := HashListForCached(doc, cache); err != nil {
+			t.Fatalf("HashListForCached: %v", err)
+		}
+	}
+
+	if len(evicted) == 0 {
+		t.Fatalf("expected at least one eviction once past maxEntries, got none")
+	}
+
+	keyA, _ := cacheKeyFor(a)
+	if _, hit := cache.get(keyA); hit {
+		t.Fatalf("expected a's subtree to have been evicted")
+	}
+}
@@ -0,0 +1,130 @@
+package mendoza
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"sync"
+)
+
+// FileEntryStore is an EntryStore backed by random-access I/O rather than
+// memory, so a StreamingHashList can hash a document far larger than RAM.
+// Each entry is written as an independent, self-contained gob record at the
+// next free offset; a parallel in-memory (offset, length) index, a few bytes
+// per entry, is all that's kept around to find them again, rather than the
+// entries (let alone the original document) themselves.
+//
+// This uses plain ReadAt/WriteAt rather than an actual memory-mapped file:
+// that gives the same O(1)-seek random access FileHashListReader needs
+// without reaching for a platform-specific mmap syscall, at the cost of one
+// extra copy per entry through the kernel. For the record sizes involved
+// here (a handful of ints and a hash per node) that tradeoff is a clear win.
+type FileEntryStore struct {
+	w io.WriterAt
+
+	mu      sync.Mutex
+	cursor  int64
+	offsets []int64
+	lengths []int32
+}
+
+func NewFileEntryStore(w io.WriterAt) *FileEntryStore {
+	return &FileEntryStore{w: w}
+}
+
+func (s *FileEntryStore) Reserve() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := len(s.offsets)
+	s.offsets = append(s.offsets, -1)
+	s.lengths = append(s.lengths, 0)
+	return idx
+}
+
+func (s *FileEntryStore) Write(idx int, entry HashEntry) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entry); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	offset := s.cursor
+	s.cursor += int64(buf.Len())
+	s.offsets[idx] = offset
+	s.lengths[idx] = int32(buf.Len())
+	s.mu.Unlock()
+
+	_, err := s.w.WriteAt(buf.Bytes(), offset)
+	return err
+}
+
+// NewReader returns a HashListReader over the entries written so far. It's
+// only meaningful to call once every entry's Write has completed, i.e. after
+// the StreamingHashList driving this store has returned a Result.
+func (s *FileEntryStore) NewReader(r io.ReaderAt) *FileHashListReader {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return &FileHashListReader{
+		r:       r,
+		offsets: append([]int64(nil), s.offsets...),
+		lengths: append([]int32(nil), s.lengths...),
+	}
+}
+
+// FileHashListReader is a HashListReader over a file (or other ReaderAt)
+// written by a FileEntryStore.
+type FileHashListReader struct {
+	r       io.ReaderAt
+	offsets []int64
+	lengths []int32
+}
+
+func (fr *FileHashListReader) Len() int {
+	return len(fr.offsets)
+}
+
+func (fr *FileHashListReader) Entry(idx int) HashEntry {
+	entry, err := fr.readEntry(idx)
+	if err != nil {
+		// HashListReader.Entry has no error return, matching HashList's own
+		// Entries[idx]: both assume idx is valid, and a corrupt or truncated
+		// backing file is a programmer error, not a recoverable one.
+		panic(err)
+	}
+	return entry
+}
+
+func (fr *FileHashListReader) readEntry(idx int) (HashEntry, error) {
+	buf := make([]byte, fr.lengths[idx])
+	if _, err := fr.r.ReadAt(buf, fr.offsets[idx]); err != nil {
+		return HashEntry{}, err
+	}
+
+	var entry HashEntry
+	err := gob.NewDecoder(bytes.NewReader(buf)).Decode(&entry)
+	return entry, err
+}
+
+func (fr *FileHashListReader) IsNonEmptyMap(idx int) bool {
+	if fr.Len() <= idx+1 {
+		return false
+	}
+
+	next := fr.Entry(idx + 1)
+	return next.Parent == idx && next.Reference.IsMapEntry()
+}
+
+func (fr *FileHashListReader) IsNonEmptySlice(idx int) bool {
+	if fr.Len() <= idx+1 {
+		return false
+	}
+
+	next := fr.Entry(idx + 1)
+	return next.Parent == idx && next.Reference.IsSliceEntry()
+}
+
+func (fr *FileHashListReader) Iter(idx int) *Iter {
+	return &Iter{source: fr, idx: idx + 1}
+}
@@ -0,0 +1,127 @@
+package mendoza
+
+// HashListForCached behaves like HashListFor, but consults cache before
+// walking any map or slice value: if the exact same object (by pointer
+// identity — see cacheKey) was hashed before, its cached entries are spliced
+// in directly instead of recursing into it again. This is the case that
+// matters when diffing one document against many near-identical variants
+// that share unchanged sub-objects by reference, or vice versa.
+func HashListForCached(doc interface{}, cache *HashListCache) (*HashList, error) {
+	hashList := &HashList{}
+	store := &memoryHashListStore{hashList: hashList}
+	s := NewStreamingHashList(store)
+
+	if err := streamValueCached(s, hashList, doc, cache); err != nil {
+		return nil, err
+	}
+	if _, err := s.Result(); err != nil {
+		return nil, err
+	}
+
+	return hashList, nil
+}
+
+func streamValueCached(s *StreamingHashList, hashList *HashList, obj interface{}, cache *HashListCache) error {
+	switch obj := obj.(type) {
+	case map[string]interface{}:
+		key, cacheable := cacheKeyFor(obj)
+		if cacheable {
+			if cached, hit := cache.get(key); hit {
+				idx, err := s.SpliceSubtree(cached)
+				if err != nil {
+					return err
+				}
+				hashList.Entries[idx].Value = obj
+				return nil
+			}
+		}
+
+		idx, err := s.BeginMap()
+		if err != nil {
+			return err
+		}
+		hashList.Entries[idx].Value = obj
+
+		for _, k := range sortedKeys(obj) {
+			if err := s.Key(k); err != nil {
+				return err
+			}
+			if err := streamValueCached(s, hashList, obj[k], cache); err != nil {
+				return err
+			}
+		}
+
+		entry, err := s.EndValue()
+		if err != nil {
+			return err
+		}
+		if cacheable {
+			snapshotSubtree(hashList, idx, entry, key, obj, cache)
+		}
+		return nil
+	case []interface{}:
+		key, cacheable := cacheKeyFor(obj)
+		if cacheable {
+			if cached, hit := cache.get(key); hit {
+				idx, err := s.SpliceSubtree(cached)
+				if err != nil {
+					return err
+				}
+				hashList.Entries[idx].Value = obj
+				return nil
+			}
+		}
+
+		idx, err := s.BeginSlice()
+		if err != nil {
+			return err
+		}
+		hashList.Entries[idx].Value = obj
+
+		for _, elem := range obj {
+			if err := streamValueCached(s, hashList, elem, cache); err != nil {
+				return err
+			}
+		}
+
+		entry, err := s.EndValue()
+		if err != nil {
+			return err
+		}
+		if cacheable {
+			snapshotSubtree(hashList, idx, entry, key, obj, cache)
+		}
+		return nil
+	default:
+		// Scalars are cheap enough to hash that caching them individually
+		// isn't worthwhile; fall back to the uncached walk.
+		return streamValue(s, hashList, obj)
+	}
+}
+
+// snapshotSubtree snapshots hashList.Entries[idx:] — the subtree that just
+// finished closing at idx, with entry as its own (not yet written) finalized
+// row — into the cache, rebased to be relative to idx so it can be spliced
+// back in anywhere later via StreamingHashList.SpliceSubtree. Nothing has
+// been appended to hashList.Entries since idx's children were written, so
+// the current length is exactly the subtree's end.
+func snapshotSubtree(hashList *HashList, idx int, entry HashEntry, key cacheKey, source interface{}, cache *HashListCache) {
+	end := len(hashList.Entries)
+
+	rel := make([]HashEntry, end-idx)
+	rel[0] = entry
+	rel[0].Parent = -1
+	rel[0].Reference = Reference{}
+	rel[0].Sibling = -1
+
+	for i := idx + 1; i < end; i++ {
+		child := hashList.Entries[i]
+		child.Parent -= idx
+		if child.Sibling != -1 {
+			child.Sibling -= idx
+		}
+		rel[i-idx] = child
+	}
+
+	cache.add(key, source, rel)
+}
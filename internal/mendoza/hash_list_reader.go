@@ -0,0 +1,19 @@
+package mendoza
+
+// HashListReader is read-only, random access over a flattened, hashed
+// document: the same shape HashList holds in memory, but potentially backed
+// by a store too large to fit there. The differ is written against this
+// interface, rather than the concrete *HashList, so it can diff documents
+// larger than RAM as long as both sides are exposed as a HashListReader.
+//
+// Implementations must preserve the property HashList itself relies on: a
+// node's entry index is assigned in pre-order (a node always has a lower
+// index than every entry in its subtree), so a node's first child, if any,
+// is always at idx+1.
+type HashListReader interface {
+	Len() int
+	Entry(idx int) HashEntry
+	IsNonEmptyMap(idx int) bool
+	IsNonEmptySlice(idx int) bool
+	Iter(idx int) *Iter
+}
@@ -0,0 +1,408 @@
+package mendoza
+
+import "errors"
+
+// EntryStore is the pluggable backing a StreamingHashList writes finalized
+// HashEntry rows to. Entries are addressed by the same pre-order index
+// HashList assigns in memory: Reserve hands out a node's index the moment it
+// is opened (before its hash, size, or sibling are known), and Write stores
+// the entry, now fully finalized, once they are.
+type EntryStore interface {
+	// Reserve allocates the next pre-order index, without writing anything.
+	Reserve() int
+
+	// Write stores the finalized entry at idx, previously returned by Reserve.
+	// It is called at most once per index.
+	Write(idx int, entry HashEntry) error
+}
+
+// memoryHashListStore adapts a *HashList to the EntryStore interface, so
+// HashList.AddDocument can build an ordinary in-memory HashList through
+// StreamingHashList rather than a separate recursive implementation.
+type memoryHashListStore struct {
+	hashList *HashList
+}
+
+func (s *memoryHashListStore) Reserve() int {
+	idx := len(s.hashList.Entries)
+	s.hashList.Entries = append(s.hashList.Entries, HashEntry{})
+	return idx
+}
+
+func (s *memoryHashListStore) Write(idx int, entry HashEntry) error {
+	// Preserve whatever Value streamValue already attached to this slot, but
+	// only when entry itself doesn't already carry one: StreamingHashList
+	// never sets Value on the entries it builds from a token stream, so the
+	// ordinary case is restoring what streamValue/streamValueCached stashed
+	// on the slot directly. SpliceSubtree is the exception — a cached
+	// subtree's interior rows have no slot-side Value to restore, since
+	// nothing ever called streamValueCached on them this time around; their
+	// Value travels as part of entry itself and must win here.
+	if entry.Value == nil {
+		entry.Value = s.hashList.Entries[idx].Value
+	}
+	s.hashList.Entries[idx] = entry
+	return nil
+}
+
+// streamFrame is an open map or slice node: the node's own identity (idx,
+// parent, reference) plus enough running state to finish hashing it once End
+// is called, and the one not-yet-flushed child entry whose Sibling link is
+// still waiting on whichever comes first, its next sibling or frame close.
+type streamFrame struct {
+	idx    int
+	parent int
+	ref    Reference
+	size   int
+
+	isMap  bool
+	mapH   mapHasher
+	sliceH sliceHasher
+
+	fieldIdx      int
+	nextElem      int
+	pendingKey    string
+	pendingKeySet bool
+
+	havePending  bool
+	pendingIdx   int
+	pendingEntry HashEntry
+}
+
+// mapHasher and sliceHasher capture just the methods streamFrame uses from
+// HasherMap()/HasherSlice(); declaring them locally, instead of
+// importing the concrete hasher types, keeps streamFrame decoupled from
+// exactly how WriteField/WriteElement/Sum are implemented.
+type mapHasher interface {
+	WriteField(key string, hash Hash)
+	Sum() Hash
+}
+
+type sliceHasher interface {
+	WriteElement(hash Hash)
+	Sum() Hash
+}
+
+// StreamingHashList builds the same HashEntry rows HashList.AddDocument does,
+// but incrementally: callers drive it with one method per token of a
+// document walk (BeginMap, Key, BeginSlice, String, Float, Bool, Null, End),
+// the same shape a json.Decoder produces, and it never retains more than the
+// path of currently-open frames. Each finished HashEntry is handed to an
+// EntryStore as soon as it's known, so the rest of the document doesn't need
+// to be in memory.
+type StreamingHashList struct {
+	store EntryStore
+	stack []*streamFrame
+
+	done   bool
+	result Hash
+}
+
+func NewStreamingHashList(store EntryStore) *StreamingHashList {
+	return &StreamingHashList{store: store}
+}
+
+// Result returns the hash of the completed document. It's only valid after a
+// top-level value (a scalar, or a BeginMap/BeginSlice matched by End) has
+// been streamed in full.
+func (s *StreamingHashList) Result() (Hash, error) {
+	if !s.done {
+		return s.result, errors.New("mendoza: streaming hash list is not complete")
+	}
+	return s.result, nil
+}
+
+func (s *StreamingHashList) BeginMap() (int, error) {
+	parent, ref, err := s.openChild()
+	if err != nil {
+		return 0, err
+	}
+
+	idx := s.store.Reserve()
+	s.stack = append(s.stack, &streamFrame{idx: idx, parent: parent, ref: ref, isMap: true, mapH: HasherMap()})
+	return idx, nil
+}
+
+func (s *StreamingHashList) BeginSlice() (int, error) {
+	parent, ref, err := s.openChild()
+	if err != nil {
+		return 0, err
+	}
+
+	idx := s.store.Reserve()
+	s.stack = append(s.stack, &streamFrame{idx: idx, parent: parent, ref: ref, sliceH: HasherSlice()})
+	return idx, nil
+}
+
+// Key sets the field name of the map value that follows. It must be called
+// once before every value nested directly inside a BeginMap/End pair, with
+// keys in the same sorted order AddDocument's recursive walk uses.
+func (s *StreamingHashList) Key(name string) error {
+	if len(s.stack) == 0 {
+		return errors.New("mendoza: Key outside of a map")
+	}
+
+	top := s.stack[len(s.stack)-1]
+	if !top.isMap {
+		return errors.New("mendoza: Key inside a slice")
+	}
+
+	top.pendingKey = name
+	top.pendingKeySet = true
+	return nil
+}
+
+func (s *StreamingHashList) String(value string) (int, error) {
+	return s.pushScalar(HashString(value), len(value)+1)
+}
+
+func (s *StreamingHashList) Float(value float64) (int, error) {
+	return s.pushScalar(HashFloat64(value), 8)
+}
+
+func (s *StreamingHashList) Bool(value bool) (int, error) {
+	if value {
+		return s.pushScalar(HashTrue, 1)
+	}
+	return s.pushScalar(HashFalse, 1)
+}
+
+func (s *StreamingHashList) Null() (int, error) {
+	return s.pushScalar(HashNull, 1)
+}
+
+func (s *StreamingHashList) pushScalar(hash Hash, size int) (int, error) {
+	parent, ref, err := s.openChild()
+	if err != nil {
+		return 0, err
+	}
+
+	idx := s.store.Reserve()
+	entry := HashEntry{Parent: parent, Reference: ref, Hash: hash, Size: size, Sibling: -1}
+	return idx, s.finishChild(idx, entry)
+}
+
+// End closes the most recently opened BeginMap/BeginSlice frame.
+func (s *StreamingHashList) End() error {
+	_, err := s.EndValue()
+	return err
+}
+
+// EndValue is End, but also returns the entry the closed frame finalized to
+// (with a placeholder Sibling of -1, since that's only known once whatever
+// comes next, if anything, does): HashListForCached uses it to snapshot a
+// subtree for the cache right as it closes, without waiting for — and
+// possibly racing — the store to actually persist it.
+func (s *StreamingHashList) EndValue() (HashEntry, error) {
+	if len(s.stack) == 0 {
+		return HashEntry{}, errors.New("mendoza: unbalanced End")
+	}
+
+	frame := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+
+	var hash Hash
+	if frame.isMap {
+		hash = frame.mapH.Sum()
+	} else {
+		hash = frame.sliceH.Sum()
+	}
+
+	if frame.havePending {
+		pending := frame.pendingEntry
+		pending.Sibling = -1
+		if err := s.store.Write(frame.pendingIdx, pending); err != nil {
+			return HashEntry{}, err
+		}
+	}
+
+	entry := HashEntry{Parent: frame.parent, Reference: frame.ref, Hash: hash, Size: frame.size, Sibling: -1}
+	return entry, s.finishChild(frame.idx, entry)
+}
+
+// SpliceSubtree injects entries as the next child without walking it again:
+// entries must be a subtree exactly as HashListForCached captures it — index
+// 0 is the subtree's own root (with a placeholder Parent of -1), every other
+// index is relative to it, and Sibling links are either -1 (no next sibling
+// within the subtree) or another subtree-relative index. It's the building
+// block a HashListCache hit uses in place of BeginMap/BeginSlice plus
+// recursion.
+func (s *StreamingHashList) SpliceSubtree(entries []HashEntry) (int, error) {
+	if len(entries) == 0 {
+		return 0, errors.New("mendoza: empty subtree")
+	}
+
+	parent, ref, err := s.openChild()
+	if err != nil {
+		return 0, err
+	}
+
+	base := s.store.Reserve()
+	for i := 1; i < len(entries); i++ {
+		s.store.Reserve()
+	}
+
+	var rootEntry HashEntry
+
+	for i, entry := range entries {
+		idx := base + i
+
+		if entry.Parent == -1 {
+			entry.Parent = parent
+			entry.Reference = ref
+		} else {
+			entry.Parent = base + entry.Parent
+		}
+		if entry.Sibling != -1 {
+			entry.Sibling = base + entry.Sibling
+		}
+
+		if i == 0 {
+			rootEntry = entry
+			continue // the root's Write is handled by finishChild below
+		}
+		if err := s.store.Write(idx, entry); err != nil {
+			return 0, err
+		}
+	}
+
+	return base, s.finishChild(base, rootEntry)
+}
+
+// openChild consumes the current top frame's positional context (a map's
+// pending key, or a slice's running element counter) to produce the
+// reference a new child node is attached under, along with its parent index.
+// It returns (-1, Reference{}) when the stack is empty: the node about to be
+// opened is the document root.
+func (s *StreamingHashList) openChild() (parent int, ref Reference, err error) {
+	if len(s.stack) == 0 {
+		return -1, Reference{}, nil
+	}
+
+	top := s.stack[len(s.stack)-1]
+
+	if top.isMap {
+		if !top.pendingKeySet {
+			return 0, Reference{}, errors.New("mendoza: map value without a preceding Key")
+		}
+		ref = MapEntryReference(top.fieldIdx, top.pendingKey)
+		top.fieldIdx++
+		top.pendingKeySet = false
+		return top.idx, ref, nil
+	}
+
+	ref = SliceEntryReference(top.nextElem)
+	top.nextElem++
+	return top.idx, ref, nil
+}
+
+// finishChild registers a just-finalized entry (everything but its Sibling)
+// with whatever it's nested inside of: the enclosing frame's hasher and
+// size, and the one-entry-deep buffer that lets Sibling be patched in once
+// it's known, without ever holding more than that.
+func (s *StreamingHashList) finishChild(idx int, entry HashEntry) error {
+	if len(s.stack) == 0 {
+		entry.Sibling = -1
+		if err := s.store.Write(idx, entry); err != nil {
+			return err
+		}
+		s.result = entry.Hash
+		s.done = true
+		return nil
+	}
+
+	top := s.stack[len(s.stack)-1]
+
+	if top.isMap {
+		top.mapH.WriteField(entry.Reference.Key, entry.Hash)
+		top.size += len(entry.Reference.Key) + entry.Size + 1
+	} else {
+		top.sliceH.WriteElement(entry.Hash)
+		top.size += entry.Size + 1
+	}
+
+	if top.havePending {
+		pending := top.pendingEntry
+		pending.Sibling = idx
+		if err := s.store.Write(top.pendingIdx, pending); err != nil {
+			return err
+		}
+	}
+
+	top.pendingIdx = idx
+	top.pendingEntry = entry
+	top.havePending = true
+
+	return nil
+}
+
+// streamValue drives a StreamingHashList over an already-materialized
+// document, patching each entry's Value into hashList directly afterward.
+// That's safe, and cheap, only because obj is already fully in memory here —
+// unlike a token stream from a decoder, there's no extra retention cost to
+// keeping a reference to each subtree around.
+func streamValue(s *StreamingHashList, hashList *HashList, obj interface{}) error {
+	switch obj := obj.(type) {
+	case map[string]interface{}:
+		idx, err := s.BeginMap()
+		if err != nil {
+			return err
+		}
+		hashList.Entries[idx].Value = obj
+
+		for _, key := range sortedKeys(obj) {
+			if err := s.Key(key); err != nil {
+				return err
+			}
+			if err := streamValue(s, hashList, obj[key]); err != nil {
+				return err
+			}
+		}
+
+		return s.End()
+	case []interface{}:
+		idx, err := s.BeginSlice()
+		if err != nil {
+			return err
+		}
+		hashList.Entries[idx].Value = obj
+
+		for _, elem := range obj {
+			if err := streamValue(s, hashList, elem); err != nil {
+				return err
+			}
+		}
+
+		return s.End()
+	case nil:
+		idx, err := s.Null()
+		if err != nil {
+			return err
+		}
+		hashList.Entries[idx].Value = obj
+		return nil
+	case bool:
+		idx, err := s.Bool(obj)
+		if err != nil {
+			return err
+		}
+		hashList.Entries[idx].Value = obj
+		return nil
+	case float64:
+		idx, err := s.Float(obj)
+		if err != nil {
+			return err
+		}
+		hashList.Entries[idx].Value = obj
+		return nil
+	case string:
+		idx, err := s.String(obj)
+		if err != nil {
+			return err
+		}
+		hashList.Entries[idx].Value = obj
+		return nil
+	default:
+		return errors.New("unsupported type")
+	}
+}
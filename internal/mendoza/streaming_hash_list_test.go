@@ -0,0 +1,151 @@
+package mendoza
+
+import (
+	"os"
+	"testing"
+)
+
+var streamingTestDocs = []interface{}{
+	map[string]interface{}{},
+	map[string]interface{}{"a": "b"},
+	map[string]interface{}{
+		"a": "a",
+		"b": []interface{}{1.0, 2.0, "three"},
+		"c": map[string]interface{}{"nested": true, "empty": nil},
+	},
+	[]interface{}{},
+	[]interface{}{[]interface{}{1.0}, []interface{}{2.0, 3.0}},
+	"just a string",
+	42.0,
+}
+
+// driveStream replays obj's shape as a sequence of StreamingHashList token
+// calls, independently of streamValue, so the test exercises the public
+// token API the way a JSON decoder would.
+func driveStream(t *testing.T, s *StreamingHashList, obj interface{}) {
+	t.Helper()
+
+	switch obj := obj.(type) {
+	case map[string]interface{}:
+		if _, err := s.BeginMap(); err != nil {
+			t.Fatalf("BeginMap: %v", err)
+		}
+		for _, key := range sortedKeys(obj) {
+			if err := s.Key(key); err != nil {
+				t.Fatalf("Key: %v", err)
+			}
+			driveStream(t, s, obj[key])
+		}
+		if err := s.End(); err != nil {
+			t.Fatalf("End: %v", err)
+		}
+	case []interface{}:
+		if _, err := s.BeginSlice(); err != nil {
+			t.Fatalf("BeginSlice: %v", err)
+		}
+		for _, elem := range obj {
+			driveStream(t, s, elem)
+		}
+		if err := s.End(); err != nil {
+			t.Fatalf("End: %v", err)
+		}
+	case nil:
+		if _, err := s.Null(); err != nil {
+			t.Fatalf("Null: %v", err)
+		}
+	case bool:
+		if _, err := s.Bool(obj); err != nil {
+			t.Fatalf("Bool: %v", err)
+		}
+	case float64:
+		if _, err := s.Float(obj); err != nil {
+			t.Fatalf("Float: %v", err)
+		}
+	case string:
+		if _, err := s.String(obj); err != nil {
+			t.Fatalf("String: %v", err)
+		}
+	default:
+		t.Fatalf("unsupported type %T", obj)
+	}
+}
+
+func TestStreamingHashListMatchesAddDocument(t *testing.T) {
+	for i, doc := range streamingTestDocs {
+		want, err := HashListFor(doc)
+		if err != nil {
+			t.Fatalf("doc %d: HashListFor: %v", i, err)
+		}
+
+		hashList := &HashList{}
+		store := &memoryHashListStore{hashList: hashList}
+		s := NewStreamingHashList(store)
+		driveStream(t, s, doc)
+
+		result, err := s.Result()
+		if err != nil {
+			t.Fatalf("doc %d: Result: %v", i, err)
+		}
+		if result != want.Entries[0].Hash {
+			t.Fatalf("doc %d: hash mismatch: got %v, want %v", i, result, want.Entries[0].Hash)
+		}
+
+		if len(hashList.Entries) != len(want.Entries) {
+			t.Fatalf("doc %d: entry count mismatch: got %d, want %d", i, len(hashList.Entries), len(want.Entries))
+		}
+
+		for idx := range want.Entries {
+			gotEntry, wantEntry := hashList.Entries[idx], want.Entries[idx]
+			gotEntry.Value, wantEntry.Value = nil, nil // driveStream doesn't attach values
+			if gotEntry != wantEntry {
+				t.Fatalf("doc %d: entry %d mismatch: got %+v, want %+v", i, idx, gotEntry, wantEntry)
+			}
+		}
+	}
+}
+
+func TestFileEntryStoreMatchesMemory(t *testing.T) {
+	doc := streamingTestDocs[2]
+
+	want, err := HashListFor(doc)
+	if err != nil {
+		t.Fatalf("HashListFor: %v", err)
+	}
+
+	f, err := os.CreateTemp(t.TempDir(), "mendoza-hashlist-*")
+	if err != nil {
+		t.Fatalf("CreateTemp: %v", err)
+	}
+	defer f.Close()
+
+	store := NewFileEntryStore(f)
+	s := NewStreamingHashList(store)
+	driveStream(t, s, doc)
+
+	result, err := s.Result()
+	if err != nil {
+		t.Fatalf("Result: %v", err)
+	}
+	if result != want.Entries[0].Hash {
+		t.Fatalf("hash mismatch: got %v, want %v", result, want.Entries[0].Hash)
+	}
+
+	reader := store.NewReader(f)
+	if reader.Len() != len(want.Entries) {
+		t.Fatalf("entry count mismatch: got %d, want %d", reader.Len(), len(want.Entries))
+	}
+
+	for idx := range want.Entries {
+		got, wantEntry := reader.Entry(idx), want.Entries[idx]
+		// Unlike the in-memory cache path (HashListForCached), FileEntryStore
+		// is driven by driveStream, which never attaches a Value in the first
+		// place, and gob-encodes whatever it's given either way — there's no
+		// source-derived Value here to compare against want's, by design
+		// (see FileEntryStore's doc comment). Zeroing both sides before
+		// comparing reflects that, rather than hiding a real mismatch.
+		got.Value, wantEntry.Value = nil, nil
+		if got != wantEntry {
+			t.Fatalf("entry %d mismatch: got %+v, want %+v", idx, got, wantEntry)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package mendoza
+
+import "strings"
+
+// Defaults for the string delta matcher, mirroring the knobs git exposes for
+// pack-file deltas: a small window keeps the index cheap to build, while the
+// chain cap bounds worst-case scan cost on pathological inputs (e.g. a string
+// that is mostly one repeated byte).
+const (
+	DefaultStringDeltaMinSize    = 64
+	DefaultStringDeltaWindowSize = 16
+	DefaultStringDeltaMaxChain   = 64
+)
+
+// StringOp is a single step in reconstructing a target string from a source
+// string: either a run copied verbatim from the source, or a literal run of
+// bytes that weren't found there.
+type StringOp interface {
+	isStringOp()
+}
+
+// StringCopy copies Length bytes from the source string starting at SrcOffset.
+type StringCopy struct {
+	SrcOffset int
+	Length    int
+}
+
+func (StringCopy) isStringOp() {}
+
+// StringInsert inserts Literal verbatim.
+type StringInsert struct {
+	Literal string
+}
+
+func (StringInsert) isStringOp() {}
+
+// stringIndex is a chained hash table over fixed-size windows of a source
+// string, used to find candidate copy sources when diffing a target string
+// against it. It is built once per source string and probed once per byte of
+// the target.
+type stringIndex struct {
+	src        string
+	windowSize int
+	maxChain   int
+	buckets    map[uint32]int // window hash -> most recently seen offset
+	prev       []int          // offset -> earlier offset with the same hash, or -1
+}
+
+func newStringIndex(src string, windowSize, maxChain int) *stringIndex {
+	idx := &stringIndex{
+		src:        src,
+		windowSize: windowSize,
+		maxChain:   maxChain,
+		buckets:    make(map[uint32]int),
+	}
+
+	if windowSize <= 0 || len(src) < windowSize {
+		return idx
+	}
+
+	idx.prev = make([]int, len(src)-windowSize+1)
+
+	for offset := 0; offset <= len(src)-windowSize; offset++ {
+		h := windowHash(src[offset : offset+windowSize])
+		if prevOffset, ok := idx.buckets[h]; ok {
+			idx.prev[offset] = prevOffset
+		} else {
+			idx.prev[offset] = -1
+		}
+		idx.buckets[h] = offset
+	}
+
+	return idx
+}
+
+// windowHash is FNV-1a; it only needs to be a cheap, well-distributed
+// fingerprint, not cryptographically strong.
+func windowHash(window string) uint32 {
+	const (
+		offsetBasis = 2166136261
+		prime       = 16777619
+	)
+
+	h := uint32(offsetBasis)
+	for i := 0; i < len(window); i++ {
+		h ^= uint32(window[i])
+		h *= prime
+	}
+	return h
+}
+
+// findMatch looks for the longest run starting at targetOffset that also
+// occurs in the source string, scanning at most maxChain candidates sharing
+// the window's hash and greedily extending each one forward.
+func (idx *stringIndex) findMatch(target string, targetOffset int) (srcOffset, length int, ok bool) {
+	if idx.windowSize <= 0 || targetOffset+idx.windowSize > len(target) {
+		return 0, 0, false
+	}
+
+	window := target[targetOffset : targetOffset+idx.windowSize]
+	h := windowHash(window)
+
+	candidate, exists := idx.buckets[h]
+	if !exists {
+		return 0, 0, false
+	}
+
+	bestOffset, bestLength := -1, 0
+
+	for chain := 0; candidate != -1 && chain < idx.maxChain; chain, candidate = chain+1, idx.prev[candidate] {
+		if idx.src[candidate:candidate+idx.windowSize] != window {
+			continue
+		}
+
+		run := idx.windowSize
+		for candidate+run < len(idx.src) && targetOffset+run < len(target) &&
+			idx.src[candidate+run] == target[targetOffset+run] {
+			run++
+		}
+
+		if run > bestLength {
+			bestOffset, bestLength = candidate, run
+		}
+	}
+
+	if bestOffset == -1 {
+		return 0, 0, false
+	}
+
+	return bestOffset, bestLength, true
+}
+
+// diff walks target byte by byte, turning matches found via the index into
+// StringCopy ops and buffering everything else into StringInsert ops. Once a
+// match is found it is also extended backward into the pending literal run,
+// so a copy that starts a few bytes into an otherwise-unmatched stretch isn't
+// short-changed by the forward-only window probe.
+func (idx *stringIndex) diff(target string) []StringOp {
+	var ops []StringOp
+
+	literalStart := 0
+	pos := 0
+
+	for pos < len(target) {
+		srcOffset, length, ok := idx.findMatch(target, pos)
+		if !ok {
+			pos++
+			continue
+		}
+
+		back := 0
+		for pos-back-1 >= literalStart && srcOffset-back-1 >= 0 &&
+			idx.src[srcOffset-back-1] == target[pos-back-1] {
+			back++
+		}
+
+		matchStart := pos - back
+		matchSrcOffset := srcOffset - back
+		matchLength := back + length
+
+		if matchStart > literalStart {
+			ops = append(ops, StringInsert{Literal: target[literalStart:matchStart]})
+		}
+		ops = append(ops, StringCopy{SrcOffset: matchSrcOffset, Length: matchLength})
+
+		pos = matchStart + matchLength
+		literalStart = pos
+	}
+
+	if literalStart < len(target) {
+		ops = append(ops, StringInsert{Literal: target[literalStart:]})
+	}
+
+	return ops
+}
+
+// DiffStrings computes a sequence of StringOps that reconstruct target from
+// src, indexing src by sliding a windowSize-byte window over it and chaining
+// same-hash windows up to maxChainLength deep, the same approach git uses to
+// build pack-file deltas.
+func DiffStrings(src, target string, windowSize, maxChainLength int) []StringOp {
+	return newStringIndex(src, windowSize, maxChainLength).diff(target)
+}
+
+// ApplyStringOps reconstructs the string produced by DiffStrings(src, target, ...)
+// given src and the resulting ops.
+func ApplyStringOps(src string, ops []StringOp) string {
+	var b strings.Builder
+
+	for _, op := range ops {
+		switch op := op.(type) {
+		case StringCopy:
+			b.WriteString(src[op.SrcOffset : op.SrcOffset+op.Length])
+		case StringInsert:
+			b.WriteString(op.Literal)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package mendoza
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffStringsRoundtrip(t *testing.T) {
+	cases := []struct {
+		name   string
+		src    string
+		target string
+	}{
+		{"identical", strings.Repeat("the quick brown fox ", 5), strings.Repeat("the quick brown fox ", 5)},
+		{"empty-src", "", "hello world"},
+		{"empty-target", "hello world", ""},
+		{"append", strings.Repeat("a", 100), strings.Repeat("a", 100) + "tail"},
+		{"prepend", strings.Repeat("a", 100), "head" + strings.Repeat("a", 100)},
+		{"middle-insert", strings.Repeat("a", 200), strings.Repeat("a", 100) + "NEW" + strings.Repeat("a", 100)},
+		{"no-overlap", strings.Repeat("a", 80), strings.Repeat("b", 80)},
+		{"reordered-blocks", strings.Repeat("x", 80) + strings.Repeat("y", 80), strings.Repeat("y", 80) + strings.Repeat("x", 80)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ops := DiffStrings(tc.src, tc.target, DefaultStringDeltaWindowSize, DefaultStringDeltaMaxChain)
+			result := ApplyStringOps(tc.src, ops)
+			if result != tc.target {
+				t.Fatalf("roundtrip mismatch: got %q, want %q", result, tc.target)
+			}
+		})
+	}
+}
+
+func TestDiffStringsEmitsCopyForLargeSharedRegion(t *testing.T) {
+	src := strings.Repeat("the quick brown fox jumps over the lazy dog. ", 10)
+	target := src + "one more sentence at the end."
+
+	ops := DiffStrings(src, target, DefaultStringDeltaWindowSize, DefaultStringDeltaMaxChain)
+
+	var copied int
+	for _, op := range ops {
+		if c, ok := op.(StringCopy); ok {
+			copied += c.Length
+		}
+	}
+
+	if copied < len(src) {
+		t.Fatalf("expected the shared prefix to be copied, only copied %d of %d bytes", copied, len(src))
+	}
+}
@@ -0,0 +1,378 @@
+package mendoza
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// opBinaryCodec knows how to collect the string literals a single Op variant
+// references and how to write/read its type-specific payload, excluding the
+// 1-byte type tag that the framing in this file owns. Each file defining an
+// Op variant registers its own codec via registerOpBinaryCodec in an init
+// func, so this file doesn't need to know about every opcode that exists.
+type opBinaryCodec struct {
+	// collectSymbols is optional; ops with no key or string literal payload
+	// (e.g. a plain copy-by-index) can leave it nil.
+	collectSymbols func(op Op, symbols *symbolTable)
+	encode         func(w *binaryWriter, op Op)
+	decode         func(r *binaryReader) (Op, error)
+}
+
+var opBinaryCodecs = map[OpCode]opBinaryCodec{}
+
+func registerOpBinaryCodec(code OpCode, codec opBinaryCodec) {
+	opBinaryCodecs[code] = codec
+}
+
+// MarshalBinary encodes p as a length-prefixed binary patch: a 4-byte
+// little-endian length of the body that follows (not counting the prefix
+// itself), then a body made of a symbol table deduplicating every key and string literal used by
+// p's ops, and then each op as a 1-byte type tag followed by a type-specific
+// payload of varint integers, symbol references, and little-endian float64s.
+func (p Patch) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := p.EncodeTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeTo streams p to w in the same format as MarshalBinary.
+func (p Patch) EncodeTo(w io.Writer) error {
+	symbols := newSymbolTable()
+	for _, op := range p.Ops {
+		codec, ok := opBinaryCodecs[op.Code()]
+		if !ok {
+			return fmt.Errorf("mendoza: no binary codec registered for opcode %d", op.Code())
+		}
+		if codec.collectSymbols != nil {
+			codec.collectSymbols(op, symbols)
+		}
+	}
+
+	bw := &binaryWriter{symbols: symbols}
+	symbols.writeTo(bw)
+	bw.writeUvarint(uint64(len(p.Ops)))
+
+	for _, op := range p.Ops {
+		bw.writeByte(byte(op.Code()))
+		opBinaryCodecs[op.Code()].encode(bw, op)
+	}
+
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(bw.buf.Len()))
+
+	if _, err := w.Write(length[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(bw.buf.Bytes())
+	return err
+}
+
+// UnmarshalBinary decodes data produced by MarshalBinary into p.
+func (p *Patch) UnmarshalBinary(data []byte) error {
+	decoded, err := DecodePatchFrom(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	*p = decoded
+	return nil
+}
+
+// DecodePatchFrom reads a single patch, in the format written by EncodeTo,
+// from r.
+func DecodePatchFrom(r io.Reader) (Patch, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return Patch{}, err
+	}
+
+	body := make([]byte, binary.LittleEndian.Uint32(length[:]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return Patch{}, err
+	}
+
+	br := &binaryReader{r: bytes.NewReader(body)}
+
+	symbols, err := readSymbolTable(br)
+	if err != nil {
+		return Patch{}, err
+	}
+	br.symbols = symbols
+
+	opCount, err := br.readUvarint()
+	if err != nil {
+		return Patch{}, err
+	}
+
+	ops := make([]Op, opCount)
+	for i := range ops {
+		tag, err := br.readByte()
+		if err != nil {
+			return Patch{}, err
+		}
+
+		codec, ok := opBinaryCodecs[OpCode(tag)]
+		if !ok {
+			return Patch{}, fmt.Errorf("mendoza: unknown binary opcode %d", tag)
+		}
+
+		op, err := codec.decode(br)
+		if err != nil {
+			return Patch{}, err
+		}
+		ops[i] = op
+	}
+
+	return Patch{Ops: ops}, nil
+}
+
+// symbolTable deduplicates the key and string-literal values referenced by a
+// patch's ops, so repeated field names in large object diffs aren't written
+// out more than once.
+type symbolTable struct {
+	ids     map[string]uint64
+	symbols []string
+}
+
+func newSymbolTable() *symbolTable {
+	return &symbolTable{ids: make(map[string]uint64)}
+}
+
+// intern assigns s an ID the first time it's seen, and returns its existing ID otherwise.
+func (t *symbolTable) intern(s string) uint64 {
+	if id, ok := t.ids[s]; ok {
+		return id
+	}
+	id := uint64(len(t.symbols))
+	t.symbols = append(t.symbols, s)
+	t.ids[s] = id
+	return id
+}
+
+func (t *symbolTable) at(id uint64) (string, error) {
+	if id >= uint64(len(t.symbols)) {
+		return "", fmt.Errorf("mendoza: symbol id %d out of range", id)
+	}
+	return t.symbols[id], nil
+}
+
+func (t *symbolTable) writeTo(w *binaryWriter) {
+	w.writeUvarint(uint64(len(t.symbols)))
+	for _, s := range t.symbols {
+		w.writeUvarint(uint64(len(s)))
+		w.buf.WriteString(s)
+	}
+}
+
+func readSymbolTable(r *binaryReader) (*symbolTable, error) {
+	count, err := r.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+
+	t := newSymbolTable()
+	for i := uint64(0); i < count; i++ {
+		n, err := r.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(r.r, buf); err != nil {
+			return nil, err
+		}
+		t.symbols = append(t.symbols, string(buf))
+	}
+	return t, nil
+}
+
+// binaryWriter accumulates a patch body: the symbol table followed by the
+// tagged op stream.
+type binaryWriter struct {
+	buf     bytes.Buffer
+	symbols *symbolTable
+}
+
+func (w *binaryWriter) writeByte(b byte) { w.buf.WriteByte(b) }
+
+func (w *binaryWriter) writeUvarint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	w.buf.Write(tmp[:n])
+}
+
+func (w *binaryWriter) writeFloat64(v float64) {
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(v))
+	w.buf.Write(tmp[:])
+}
+
+// writeSymbolRef writes s as a varint ID into the writer's symbol table,
+// which must already contain s from a prior collectSymbols pass.
+func (w *binaryWriter) writeSymbolRef(s string) {
+	w.writeUvarint(w.symbols.intern(s))
+}
+
+// binaryReader parses a single patch body produced by binaryWriter.
+type binaryReader struct {
+	r       *bytes.Reader
+	symbols *symbolTable
+}
+
+func (r *binaryReader) readByte() (byte, error) { return r.r.ReadByte() }
+
+func (r *binaryReader) readUvarint() (uint64, error) {
+	return binary.ReadUvarint(r.r)
+}
+
+func (r *binaryReader) readFloat64() (float64, error) {
+	var tmp [8]byte
+	if _, err := io.ReadFull(r.r, tmp[:]); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(tmp[:])), nil
+}
+
+func (r *binaryReader) readSymbolRef() (string, error) {
+	id, err := r.readUvarint()
+	if err != nil {
+		return "", err
+	}
+	return r.symbols.at(id)
+}
+
+// Generic JSON-value encoding, for ops (such as a whole-value replacement)
+// that carry an arbitrary interface{} payload alongside indices and keys.
+const (
+	valueTagNull byte = iota
+	valueTagFalse
+	valueTagTrue
+	valueTagFloat64
+	valueTagString
+	valueTagSlice
+	valueTagMap
+)
+
+// collectValueSymbols walks v the way encodeValue does, interning every
+// string it will later write as a symbol reference.
+func collectValueSymbols(v interface{}, symbols *symbolTable) {
+	switch v := v.(type) {
+	case string:
+		symbols.intern(v)
+	case []interface{}:
+		for _, elem := range v {
+			collectValueSymbols(elem, symbols)
+		}
+	case map[string]interface{}:
+		for _, key := range sortedMapKeys(v) {
+			symbols.intern(key)
+			collectValueSymbols(v[key], symbols)
+		}
+	}
+}
+
+func encodeValue(w *binaryWriter, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		w.writeByte(valueTagNull)
+	case bool:
+		if v {
+			w.writeByte(valueTagTrue)
+		} else {
+			w.writeByte(valueTagFalse)
+		}
+	case float64:
+		w.writeByte(valueTagFloat64)
+		w.writeFloat64(v)
+	case string:
+		w.writeByte(valueTagString)
+		w.writeSymbolRef(v)
+	case []interface{}:
+		w.writeByte(valueTagSlice)
+		w.writeUvarint(uint64(len(v)))
+		for _, elem := range v {
+			if err := encodeValue(w, elem); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		w.writeByte(valueTagMap)
+		w.writeUvarint(uint64(len(v)))
+		for _, key := range sortedMapKeys(v) {
+			w.writeSymbolRef(key)
+			if err := encodeValue(w, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("mendoza: unsupported value type %T", v)
+	}
+	return nil
+}
+
+func decodeValue(r *binaryReader) (interface{}, error) {
+	tag, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case valueTagNull:
+		return nil, nil
+	case valueTagFalse:
+		return false, nil
+	case valueTagTrue:
+		return true, nil
+	case valueTagFloat64:
+		return r.readFloat64()
+	case valueTagString:
+		return r.readSymbolRef()
+	case valueTagSlice:
+		n, err := r.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		elems := make([]interface{}, n)
+		for i := range elems {
+			elem, err := decodeValue(r)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = elem
+		}
+		return elems, nil
+	case valueTagMap:
+		n, err := r.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			key, err := r.readSymbolRef()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(r)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("mendoza: unknown value tag %d", tag)
+	}
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
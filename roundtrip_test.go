@@ -92,6 +92,21 @@ func TestRoundtrip(t *testing.T) {
 			err = json.Unmarshal(json2, &parsedPatch2)
 			require.NoError(t, err)
 			require.EqualValues(t, patch2, parsedPatch2)
+
+			// And the same thing again through the binary codec.
+			bin1, err := patch1.MarshalBinary()
+			require.NoError(t, err)
+			var binPatch1 mendoza.Patch
+			err = binPatch1.UnmarshalBinary(bin1)
+			require.NoError(t, err)
+			require.EqualValues(t, patch1, binPatch1)
+
+			bin2, err := patch2.MarshalBinary()
+			require.NoError(t, err)
+			var binPatch2 mendoza.Patch
+			err = binPatch2.UnmarshalBinary(bin2)
+			require.NoError(t, err)
+			require.EqualValues(t, patch2, binPatch2)
 		})
 	}
 }
\ No newline at end of file
@@ -0,0 +1,130 @@
+package mendoza
+
+import (
+	"strings"
+
+	internalmendoza "github.com/sanity-io/mendoza/internal/mendoza"
+)
+
+// opStringCopy and opStringInsert extend the patch opcode space with a pair
+// of ops that only make sense while the differ is positioned on a string
+// value: together they let a patch rebuild a changed string from copied runs
+// of the old one plus a handful of literal insertions, instead of replacing
+// it wholesale. Both operate on the "current string" register, which is
+// established from the source-side string value when the differ (or
+// ApplyPatch) descends into a string-valued field, and is undefined
+// everywhere else.
+//
+// Unexported, along with stringCopyOp/stringInsertOp below and
+// differOptions in differ_options.go: nothing in this snapshot of the tree
+// calls diffStringValue or applyStringOps yet (see their doc comments), so
+// there's no patch that can ever contain one of these ops. Exporting an
+// opcode with no call site would commit to a public format before the code
+// that produces or consumes it exists; keep it package-private until
+// CreateDoublePatch/CreatePatch and ApplyPatch actually wire it in.
+const (
+	opStringCopy OpCode = 0xf0 + iota
+	opStringInsert
+)
+
+// stringCopyOp copies Length bytes from the current string register
+// starting at SrcOffset.
+type stringCopyOp struct {
+	SrcOffset int
+	Length    int
+}
+
+// Code implements Op.
+func (stringCopyOp) Code() OpCode { return opStringCopy }
+
+// stringInsertOp appends Literal, verbatim, to the string being rebuilt.
+type stringInsertOp struct {
+	Literal string
+}
+
+// Code implements Op.
+func (stringInsertOp) Code() OpCode { return opStringInsert }
+
+// diffStringValue builds the op sequence that reconstructs right from left
+// using the current string register, or returns nil if either value falls
+// below opts.MinSize, in which case the caller should fall back to a
+// whole-value replacement.
+//
+// Not yet called from CreateDoublePatch/CreatePatch — see the note on
+// differOptions in differ_options.go.
+func diffStringValue(left, right string, opts stringDeltaOptions) []Op {
+	if !opts.Enabled || len(left) < opts.MinSize || len(right) < opts.MinSize {
+		return nil
+	}
+
+	stringOps := internalmendoza.DiffStrings(left, right, opts.WindowSize, opts.MaxChainLength)
+
+	ops := make([]Op, len(stringOps))
+	for i, op := range stringOps {
+		switch op := op.(type) {
+		case internalmendoza.StringCopy:
+			ops[i] = stringCopyOp{SrcOffset: op.SrcOffset, Length: op.Length}
+		case internalmendoza.StringInsert:
+			ops[i] = stringInsertOp{Literal: op.Literal}
+		}
+	}
+	return ops
+}
+
+// applyStringOps reconstructs a string from the current string register
+// (src) and a sequence of stringCopyOp/stringInsertOp values, as produced by
+// diffStringValue.
+//
+// Not yet called from ApplyPatch — see the note on differOptions in
+// differ_options.go.
+func applyStringOps(src string, ops []Op) string {
+	var b strings.Builder
+
+	for _, op := range ops {
+		switch op := op.(type) {
+		case stringCopyOp:
+			b.WriteString(src[op.SrcOffset : op.SrcOffset+op.Length])
+		case stringInsertOp:
+			b.WriteString(op.Literal)
+		}
+	}
+
+	return b.String()
+}
+
+func init() {
+	registerOpBinaryCodec(opStringCopy, opBinaryCodec{
+		encode: func(w *binaryWriter, op Op) {
+			sc := op.(stringCopyOp)
+			w.writeUvarint(uint64(sc.SrcOffset))
+			w.writeUvarint(uint64(sc.Length))
+		},
+		decode: func(r *binaryReader) (Op, error) {
+			offset, err := r.readUvarint()
+			if err != nil {
+				return nil, err
+			}
+			length, err := r.readUvarint()
+			if err != nil {
+				return nil, err
+			}
+			return stringCopyOp{SrcOffset: int(offset), Length: int(length)}, nil
+		},
+	})
+
+	registerOpBinaryCodec(opStringInsert, opBinaryCodec{
+		collectSymbols: func(op Op, symbols *symbolTable) {
+			symbols.intern(op.(stringInsertOp).Literal)
+		},
+		encode: func(w *binaryWriter, op Op) {
+			w.writeSymbolRef(op.(stringInsertOp).Literal)
+		},
+		decode: func(r *binaryReader) (Op, error) {
+			literal, err := r.readSymbolRef()
+			if err != nil {
+				return nil, err
+			}
+			return stringInsertOp{Literal: literal}, nil
+		},
+	})
+}
@@ -0,0 +1,67 @@
+package mendoza
+
+import (
+	"strings"
+	"testing"
+)
+
+// Unlike the rest of this package's tests, this one lives in package mendoza
+// rather than mendoza_test: diffStringValue, applyStringOps, and the
+// stringCopyOp/stringInsertOp types they produce are unexported (see the
+// note on differOptions in differ_options.go), so there's no way to reach
+// them from outside the package.
+func TestDiffApplyStringValueRoundtrip(t *testing.T) {
+	opts := stringDeltaOptions{
+		Enabled:        true,
+		MinSize:        8,
+		WindowSize:     4,
+		MaxChainLength: 64,
+	}
+
+	cases := []struct {
+		name  string
+		left  string
+		right string
+	}{
+		{"append", strings.Repeat("a", 40), strings.Repeat("a", 40) + "tail"},
+		{"middle-insert", strings.Repeat("a", 80), strings.Repeat("a", 40) + "NEW" + strings.Repeat("a", 40)},
+		{"reordered-blocks", strings.Repeat("x", 40) + strings.Repeat("y", 40), strings.Repeat("y", 40) + strings.Repeat("x", 40)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ops := diffStringValue(tc.left, tc.right, opts)
+
+			var sawCopy bool
+			for _, op := range ops {
+				if _, ok := op.(stringCopyOp); ok {
+					sawCopy = true
+				}
+			}
+			if !sawCopy {
+				t.Fatalf("expected at least one stringCopyOp for a large shared region, got %#v", ops)
+			}
+
+			if got := applyStringOps(tc.left, ops); got != tc.right {
+				t.Fatalf("roundtrip mismatch: got %q, want %q", got, tc.right)
+			}
+		})
+	}
+}
+
+func TestDiffStringValueBelowMinSizeFallsBack(t *testing.T) {
+	opts := stringDeltaOptions{Enabled: true, MinSize: 64, WindowSize: 4, MaxChainLength: 64}
+
+	if ops := diffStringValue("short", "shorter", opts); ops != nil {
+		t.Fatalf("expected nil ops below MinSize, got %#v", ops)
+	}
+}
+
+func TestDiffStringValueDisabled(t *testing.T) {
+	opts := stringDeltaOptions{Enabled: false, MinSize: 0, WindowSize: 4, MaxChainLength: 64}
+
+	left, right := strings.Repeat("a", 100), strings.Repeat("a", 100)+"tail"
+	if ops := diffStringValue(left, right, opts); ops != nil {
+		t.Fatalf("expected nil ops when disabled, got %#v", ops)
+	}
+}